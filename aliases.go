@@ -0,0 +1,212 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// aliasSuggestion is a frequent command together with a proposed alias.
+type aliasSuggestion struct {
+	name    string
+	command string
+	count   int
+}
+
+// countCommands tallies every command in the history files. Files are
+// streamed, so memory grows with the number of distinct commands only.
+func countCommands(paths []string) map[string]int {
+	counts := make(map[string]int)
+	for _, p := range paths {
+		scanHistoryFile(p, func(cmd string, when int64) bool {
+			counts[cmd]++
+			return true
+		})
+	}
+	return counts
+}
+
+// aliasName derives a short alias from the initials of cmd's words, e.g.
+// "git commit --amend" becomes "gca". It returns "" when cmd has fewer
+// than two usable words.
+func aliasName(cmd string) string {
+	var name strings.Builder
+	for _, word := range strings.Fields(cmd) {
+		word = strings.TrimLeft(word, "-")
+		for _, r := range word {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				name.WriteRune(unicode.ToLower(r))
+			}
+			break
+		}
+	}
+	if name.Len() < 2 {
+		return ""
+	}
+	return name.String()
+}
+
+// shellReserved holds the builtins and keywords of POSIX sh, bash, zsh and
+// fish. An alias with one of these names would replace it in every shell
+// that sources the alias file, and exec.LookPath cannot see most of them.
+var shellReserved = map[string]bool{
+	// POSIX sh
+	"alias": true, "bg": true, "break": true, "case": true, "cd": true,
+	"command": true, "continue": true, "do": true, "done": true, "elif": true,
+	"else": true, "esac": true, "eval": true, "exec": true, "exit": true,
+	"export": true, "false": true, "fc": true, "fg": true, "fi": true,
+	"for": true, "getopts": true, "hash": true, "if": true, "in": true,
+	"jobs": true, "kill": true, "pwd": true, "read": true, "readonly": true,
+	"return": true, "set": true, "shift": true, "test": true, "then": true,
+	"times": true, "trap": true, "true": true, "type": true, "ulimit": true,
+	"umask": true, "unalias": true, "unset": true, "until": true, "wait": true,
+	"while": true,
+	// bash
+	"bind": true, "builtin": true, "caller": true, "compgen": true,
+	"complete": true, "compopt": true, "declare": true, "dirs": true,
+	"disown": true, "echo": true, "enable": true, "help": true,
+	"history": true, "let": true, "local": true, "logout": true,
+	"mapfile": true, "popd": true, "printf": true, "pushd": true,
+	"readarray": true, "select": true, "shopt": true, "source": true,
+	"suspend": true, "time": true, "typeset": true,
+	// zsh
+	"autoload": true, "bindkey": true, "bye": true, "chdir": true,
+	"coproc": true, "emulate": true, "float": true, "foreach": true,
+	"functions": true, "integer": true, "limit": true, "noglob": true,
+	"print": true, "pushln": true, "r": true, "rehash": true, "repeat": true,
+	"sched": true, "setopt": true, "unfunction": true, "unhash": true,
+	"unlimit": true, "unsetopt": true, "vared": true, "whence": true,
+	"where": true, "which": true, "zle": true, "zmodload": true,
+	"zstyle": true,
+	// fish
+	"abbr": true, "and": true, "argparse": true, "begin": true,
+	"block": true, "commandline": true, "contains": true, "count": true,
+	"end": true, "fish_config": true, "function": true, "funced": true,
+	"funcsave": true, "math": true, "nextd": true, "not": true, "or": true,
+	"prevd": true, "random": true, "realpath": true, "status": true,
+	"string": true, "switch": true,
+}
+
+// suggestAliases picks up to limit commands run at least minCount times and
+// at least minLength characters long, most frequent first, and names each
+// one. Names that shadow a shell builtin or keyword, an installed program
+// or an earlier suggestion get a numeric suffix.
+func suggestAliases(counts map[string]int, limit, minCount, minLength int) []aliasSuggestion {
+	var candidates []aliasSuggestion
+	for cmd, count := range counts {
+		if count >= minCount && len(cmd) >= minLength {
+			candidates = append(candidates, aliasSuggestion{command: cmd, count: count})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].count != candidates[j].count {
+			return candidates[i].count > candidates[j].count
+		}
+		if len(candidates[i].command) != len(candidates[j].command) {
+			return len(candidates[i].command) > len(candidates[j].command)
+		}
+		return candidates[i].command < candidates[j].command
+	})
+
+	used := make(map[string]bool)
+	var suggestions []aliasSuggestion
+	for _, c := range candidates {
+		if len(suggestions) == limit {
+			break
+		}
+		base := aliasName(c.command)
+		if base == "" {
+			continue
+		}
+		name := base
+		for i := 2; used[name] || shellReserved[name] || isInstalled(name); i++ {
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+		used[name] = true
+		c.name = name
+		suggestions = append(suggestions, c)
+	}
+	return suggestions
+}
+
+func isInstalled(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// fishQuote single-quotes s for fish, where backslashes and single quotes
+// are escaped inside quotes instead of closing them.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// writeAliasFile prints the suggestions as a file the given shell can
+// source: aliases for bash and zsh, abbreviations for fish.
+func writeAliasFile(suggestions []aliasSuggestion, shell string) error {
+	var line func(aliasSuggestion) string
+	switch shell {
+	case "bash", "zsh":
+		line = func(s aliasSuggestion) string {
+			return fmt.Sprintf("alias %s=%s", s.name, shellQuote(s.command))
+		}
+	case "fish":
+		line = func(s aliasSuggestion) string {
+			return fmt.Sprintf("abbr --add %s %s", s.name, fishQuote(s.command))
+		}
+	default:
+		return fmt.Errorf("unsupported shell %q (want bash, zsh or fish)", shell)
+	}
+
+	fmt.Printf("# Generated by aqs aliases — source this from your %s config\n", shell)
+	for _, s := range suggestions {
+		fmt.Printf("# used %d times\n%s\n", s.count, line(s))
+	}
+	return nil
+}
+
+// aliasesCommand implements `aqs aliases`.
+func aliasesCommand(args []string) {
+	fs := flag.NewFlagSet("aliases", flag.ExitOnError)
+	limit := fs.Int("n", 10, "Number of aliases to suggest")
+	minCount := fs.Int("min-count", 3, "Only suggest commands run at least this many times")
+	minLength := fs.Int("min-length", 12, "Only suggest commands at least this many characters long")
+	shell := fs.String("shell", "", "Print a sourceable alias file for bash, zsh or fish")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: aqs aliases [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Suggests aliases for your most frequent long commands.\n")
+		fmt.Fprintf(os.Stderr, "Use --shell to print them as a file you can source, e.g.\n")
+		fmt.Fprintf(os.Stderr, "  aqs aliases --shell zsh > ~/.aqs_aliases.zsh\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	counts := countCommands(detectHistoryPaths())
+	if len(counts) == 0 {
+		fmt.Fprintln(os.Stderr, "No history found.")
+		os.Exit(exitNoHistory)
+	}
+
+	suggestions := suggestAliases(counts, *limit, *minCount, *minLength)
+	if len(suggestions) == 0 {
+		fmt.Fprintln(os.Stderr, "No command is frequent and long enough to deserve an alias.")
+		return
+	}
+
+	if *shell != "" {
+		if err := writeAliasFile(suggestions, *shell); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	for _, s := range suggestions {
+		fmt.Printf("%5d  %-8s %s\n", s.count, s.name, s.command)
+	}
+}
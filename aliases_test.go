@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAliasName(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want string
+	}{
+		{"git commit --amend", "gca"},
+		{"docker compose up -d", "dcud"},
+		{"cd Documents/project", "cd"},
+		{"Git Status", "gs"},
+		{"npm run 2fa", "nr2"},
+		{"./build.sh --release", ""},
+		{"ls --", ""},
+		{"make", ""},
+		{"échoué tout", ""},
+	}
+	for _, tt := range tests {
+		if got := aliasName(tt.cmd); got != tt.want {
+			t.Errorf("aliasName(%q) = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestAliases(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts map[string]int
+		limit  int
+		want   []aliasSuggestion
+	}{
+		{
+			name:   "builtins are not shadowed",
+			counts: map[string]int{"cd Documents/project": 9, "fg %1 && wait": 5},
+			limit:  10,
+			want: []aliasSuggestion{
+				{"cd2", "cd Documents/project", 9},
+				{"fw", "fg %1 && wait", 5},
+			},
+		},
+		{
+			name:   "keywords are not shadowed",
+			counts: map[string]int{"deploy one-shot.yaml": 4, "fetch images": 3},
+			limit:  10,
+			want: []aliasSuggestion{
+				{"do2", "deploy one-shot.yaml", 4},
+				{"fi2", "fetch images", 3},
+			},
+		},
+		{
+			name:   "earlier suggestions keep their name",
+			counts: map[string]int{"git commit --amend": 8, "gcloud compute apply": 6},
+			limit:  10,
+			want: []aliasSuggestion{
+				{"gca", "git commit --amend", 8},
+				{"gca2", "gcloud compute apply", 6},
+			},
+		},
+		{
+			name:   "rare and short commands are skipped",
+			counts: map[string]int{"git status": 50, "git commit --amend": 2, "docker compose up": 3},
+			limit:  10,
+			want: []aliasSuggestion{
+				{"dcu", "docker compose up", 3},
+			},
+		},
+		{
+			name:   "limit",
+			counts: map[string]int{"git commit --amend": 8, "docker compose up": 6},
+			limit:  1,
+			want: []aliasSuggestion{
+				{"gca", "git commit --amend", 8},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggestAliases(tt.counts, tt.limit, 3, 12)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("suggestAliases = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// aqcEntry is one command saved in an AQC file.
+type aqcEntry struct {
+	command     string
+	name        string
+	description string
+	// confirm requires an explicit yes before the command is executed,
+	// intended for production-affecting snippets.
+	confirm bool
+}
+
+// readAQC parses the AQC file in dir. Entries are separated by "---" lines
+// and consist of the command, an optional "- Name: Description" line and
+// an optional "confirm: true" line. Lines starting with '#' are comments.
+func readAQC(dir string) []aqcEntry {
+	file, err := os.Open(filepath.Join(dir, aqcFileName))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var entries []aqcEntry
+	var cur aqcEntry
+	flush := func() {
+		if cur.command != "" {
+			entries = append(entries, cur)
+		}
+		cur = aqcEntry{}
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case line == "---":
+			flush()
+		case cur.command == "":
+			cur.command = line
+		case strings.HasPrefix(line, "- "):
+			name, desc, _ := strings.Cut(strings.TrimPrefix(line, "- "), ":")
+			cur.name = strings.TrimSpace(name)
+			cur.description = strings.TrimSpace(desc)
+		case strings.HasPrefix(line, "confirm:"):
+			cur.confirm, _ = strconv.ParseBool(strings.TrimSpace(strings.TrimPrefix(line, "confirm:")))
+		}
+	}
+	flush()
+
+	return entries
+}
+
+// mergeAQC puts the AQC commands ahead of the history items. With dedupe,
+// history occurrences of those commands are dropped.
+func mergeAQC(entries []aqcEntry, items []string, dedupe bool) []string {
+	merged := make([]string, 0, len(entries)+len(items))
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if seen[e.command] {
+			continue
+		}
+		seen[e.command] = true
+		merged = append(merged, e.command)
+	}
+	for _, item := range items {
+		if dedupe && seen[item] {
+			continue
+		}
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// needsConfirmation reports whether cmd comes from an AQC entry marked
+// with confirm: true.
+func needsConfirmation(entries []aqcEntry, cmd string) bool {
+	for _, e := range entries {
+		if e.command == cmd && e.confirm {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmCommand shows the command exactly as it will run and asks for an
+// explicit yes.
+func confirmCommand(cmd string) bool {
+	fmt.Fprintf(os.Stderr, "This command requires confirmation:\n\n    %s\n\n", cmd)
+	return askYesNo("Run it? [y/N]: ")
+}
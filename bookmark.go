@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const bookmarksFileName = "bookmarks"
+
+// cdFileEnv names the file the shell wrapper reads after AQS exits to cd
+// into a bookmark's directory.
+const cdFileEnv = "AQS_CD_FILE"
+
+// bookmark pairs a command with the directory it should run in.
+type bookmark struct {
+	dir     string
+	command string
+}
+
+// label is how a bookmark is shown in the picker.
+func (b bookmark) label() string {
+	dir := b.dir
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			dir = filepath.Join("~", rel)
+		}
+	}
+	return fmt.Sprintf("[%s] %s", dir, b.command)
+}
+
+func bookmarksPath() string {
+	dir := configDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, bookmarksFileName)
+}
+
+// readBookmarks loads the saved bookmarks, one "dir<TAB>command" per line.
+func readBookmarks() []bookmark {
+	file, err := os.Open(bookmarksPath())
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var bookmarks []bookmark
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		dir, command, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok || dir == "" || command == "" {
+			continue
+		}
+		bookmarks = append(bookmarks, bookmark{dir: dir, command: command})
+	}
+	return bookmarks
+}
+
+func writeBookmarks(bookmarks []bookmark) error {
+	p := bookmarksPath()
+	if p == "" {
+		return fmt.Errorf("cannot locate config directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, b := range bookmarks {
+		fmt.Fprintf(&sb, "%s\t%s\n", b.dir, b.command)
+	}
+	return os.WriteFile(p, []byte(sb.String()), 0644)
+}
+
+// pickBookmark lets the user choose one of bookmarks in fzf and returns its
+// index, or -1 if nothing was picked.
+func pickBookmark(bookmarks []bookmark) int {
+	labels := make([]string, len(bookmarks))
+	for i, b := range bookmarks {
+		labels[i] = b.label()
+	}
+
+	selected, sig := callFzf(labels, "", false)
+	if sig != nil {
+		os.Exit(exitCodeForSignal(sig))
+	}
+	if selected == "" {
+		if _, err := exec.LookPath("fzf"); err != nil {
+			fmt.Fprintln(os.Stderr, "fzf not found. Install fzf: brew install fzf")
+		}
+		return -1
+	}
+	for i, label := range labels {
+		if label == selected {
+			return i
+		}
+	}
+	return -1
+}
+
+// bookmarkCommand implements `aqs bookmark [add|list|rm]`.
+func bookmarkCommand(args []string) {
+	fs := flag.NewFlagSet("bookmark", flag.ExitOnError)
+	dryRun := fs.Bool("d", false, "Dry run: print selected command without executing")
+	fs.BoolVar(dryRun, "dry-run", false, "Dry run: print selected command without executing")
+	quiet := fs.Bool("q", false, "Quiet: don't echo the selected command or the Running: banner")
+	fs.BoolVar(quiet, "quiet", false, "Quiet: don't echo the selected command or the Running: banner")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: aqs bookmark [options] [add [command] | list | rm]\n\n")
+		fmt.Fprintf(os.Stderr, "Without a subcommand, pick a bookmark, cd into its directory and run it.\n")
+		fmt.Fprintf(os.Stderr, "add   Bookmark a command (picked from history if omitted) for the current directory.\n")
+		fmt.Fprintf(os.Stderr, "list  Print all bookmarks.\n")
+		fmt.Fprintf(os.Stderr, "rm    Pick a bookmark to delete.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	switch fs.Arg(0) {
+	case "":
+		runBookmark(*dryRun, *quiet)
+	case "add":
+		addBookmark(strings.Join(fs.Args()[1:], " "))
+	case "list", "ls":
+		for _, b := range readBookmarks() {
+			fmt.Println(b.label())
+		}
+	case "rm", "remove":
+		removeBookmark()
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+func addBookmark(command string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	if command == "" {
+		items := readHistory(detectHistoryPaths(), true)
+		if len(items) == 0 {
+			fmt.Fprintln(os.Stderr, "No history found.")
+			os.Exit(exitNoHistory)
+		}
+
+		fmt.Fprintln(os.Stderr, "Select a command to bookmark:")
+		var sig os.Signal
+		command, sig = callFzf(items, "", false)
+		if sig != nil {
+			os.Exit(exitCodeForSignal(sig))
+		}
+		if command == "" {
+			if _, err := exec.LookPath("fzf"); err != nil {
+				fmt.Fprintln(os.Stderr, "fzf not found. Install fzf: brew install fzf")
+			}
+			os.Exit(1)
+		}
+	}
+
+	b := bookmark{dir: cwd, command: command}
+	bookmarks := readBookmarks()
+	for _, existing := range bookmarks {
+		if existing == b {
+			fmt.Printf("Already bookmarked: %s\n", b.label())
+			return
+		}
+	}
+
+	if err := writeBookmarks(append(bookmarks, b)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing bookmarks: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Bookmarked: %s\n", b.label())
+}
+
+func removeBookmark() {
+	bookmarks := readBookmarks()
+	if len(bookmarks) == 0 {
+		fmt.Fprintln(os.Stderr, "No bookmarks found.")
+		os.Exit(2)
+	}
+
+	i := pickBookmark(bookmarks)
+	if i == -1 {
+		os.Exit(1)
+	}
+	removed := bookmarks[i]
+
+	if err := writeBookmarks(append(bookmarks[:i], bookmarks[i+1:]...)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing bookmarks: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Removed bookmark: %s\n", removed.label())
+}
+
+// runBookmark picks a bookmark and runs its command inside its directory.
+// When started through the shell wrapper, the directory is also written to
+// $AQS_CD_FILE so the calling shell ends up there.
+func runBookmark(dryRun, quiet bool) {
+	bookmarks := readBookmarks()
+	if len(bookmarks) == 0 {
+		fmt.Fprintln(os.Stderr, "No bookmarks found. Add one with: aqs bookmark add")
+		os.Exit(2)
+	}
+
+	i := pickBookmark(bookmarks)
+	if i == -1 {
+		os.Exit(1)
+	}
+	b := bookmarks[i]
+
+	if !quiet || dryRun {
+		fmt.Println(b.command)
+	}
+	if dryRun {
+		return
+	}
+
+	if cdFile := os.Getenv(cdFileEnv); cdFile != "" {
+		if err := os.WriteFile(cdFile, []byte(b.dir), 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", cdFileEnv, err)
+		}
+	}
+	os.Exit(runCommand(b.command, b.dir, quiet))
+}
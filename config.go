@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// config holds defaults read from the AQS config file. Command-line flags
+// take precedence over anything set here.
+type config struct {
+	NoDedupe bool
+}
+
+// configDir returns the directory holding AQS's global files, normally
+// ~/.config/aqs.
+func configDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "aqs")
+}
+
+// configPath returns the location of the config file.
+func configPath() string {
+	dir := configDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "config")
+}
+
+// loadConfig reads "key = value" lines from the config file. Blank lines
+// and lines starting with '#' are ignored, as are unknown keys. A missing
+// file yields the zero config.
+func loadConfig() config {
+	var cfg config
+
+	p := configPath()
+	if p == "" {
+		return cfg
+	}
+	file, err := os.Open(p)
+	if err != nil {
+		return cfg
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "no-dedupe":
+			cfg.NoDedupe, _ = strconv.ParseBool(value)
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// textEncoding identifies how a history file is encoded on disk.
+type textEncoding int
+
+const (
+	encodingUTF8 textEncoding = iota
+	encodingUTF16LE
+	encodingUTF16BE
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// detectEncoding sniffs the byte order mark at the start of f. It returns
+// the encoding and the offset at which the text begins. Files without a
+// BOM are treated as UTF-8.
+func detectEncoding(f *os.File) (textEncoding, int64) {
+	head := make([]byte, 3)
+	n, _ := f.ReadAt(head, 0)
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		return encodingUTF8, int64(len(bomUTF8))
+	case bytes.HasPrefix(head, bomUTF16LE):
+		return encodingUTF16LE, int64(len(bomUTF16LE))
+	case bytes.HasPrefix(head, bomUTF16BE):
+		return encodingUTF16BE, int64(len(bomUTF16BE))
+	}
+	return encodingUTF8, 0
+}
+
+// unitSize is the width in bytes of one code unit.
+func (e textEncoding) unitSize() int {
+	if e == encodingUTF8 {
+		return 1
+	}
+	return 2
+}
+
+// newline is the encoded form of '\n'.
+func (e textEncoding) newline() []byte {
+	switch e {
+	case encodingUTF16LE:
+		return []byte{'\n', 0}
+	case encodingUTF16BE:
+		return []byte{0, '\n'}
+	}
+	return []byte{'\n'}
+}
+
+// toUTF8 transcodes raw text in encoding e to UTF-8. UTF-8 input is
+// returned as is; unpaired UTF-16 surrogates become U+FFFD.
+func (e textEncoding) toUTF8(raw []byte) []byte {
+	if e == encodingUTF8 {
+		return raw
+	}
+
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		hi, lo := raw[2*i+1], raw[2*i]
+		if e == encodingUTF16BE {
+			hi, lo = lo, hi
+		}
+		units[i] = uint16(hi)<<8 | uint16(lo)
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// zshMeta is the byte zsh uses to escape special bytes in its history file.
+const zshMeta = 0x83
+
+// unmetafy undoes zsh's history escaping, where some bytes (including most
+// UTF-8 continuation bytes) are stored as zshMeta followed by the byte
+// XOR 32.
+func unmetafy(raw []byte) []byte {
+	if bytes.IndexByte(raw, zshMeta) == -1 {
+		return raw
+	}
+	out := make([]byte, 0, len(raw))
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == zshMeta && i+1 < len(raw) {
+			i++
+			out = append(out, raw[i]^32)
+			continue
+		}
+		out = append(out, raw[i])
+	}
+	return out
+}
+
+// sanitizeLine converts a raw history line to a valid UTF-8 string,
+// collapsing each run of undecodable bytes into a single U+FFFD.
+func sanitizeLine(raw []byte) string {
+	return strings.ToValidUTF8(string(raw), "\uFFFD")
+}
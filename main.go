@@ -4,10 +4,12 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/sahilm/fuzzy"
@@ -35,23 +37,60 @@ func printVersion() {
 }
 
 func main() {
+	// Subcommands take over the rest of the command line
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "bookmark":
+			bookmarkCommand(os.Args[2:])
+			return
+		case "prune":
+			pruneCommand(os.Args[2:])
+			return
+		case "aliases":
+			aliasesCommand(os.Args[2:])
+			return
+		case "hook":
+			hookCommand(os.Args[2:])
+			return
+		}
+	}
+
+	cfg := loadConfig()
+
 	dryRun := flag.Bool("d", false, "Dry run: print selected command without executing")
 	flag.BoolVar(dryRun, "dry-run", false, "Dry run: print selected command without executing")
 	addAQC := flag.Bool("a", false, "Add a command to the AQC file in current directory")
 	flag.BoolVar(addAQC, "add", false, "Add a command to the AQC file in current directory")
+	selectFirst := flag.Bool("1", false, "Skip the picker when exactly one command confidently matches the query")
+	flag.BoolVar(selectFirst, "select-first", false, "Skip the picker when exactly one command confidently matches the query")
+	first := flag.Bool("first", false, "Skip the picker and take the top-ranked command")
+	forceExec := flag.Bool("force-exec", false, "Execute the top-ranked command even when no terminal is attached")
+	noDedupe := flag.Bool("no-dedupe", cfg.NoDedupe, "Keep every occurrence of a command instead of only the most recent")
+	session := flag.Bool("session", false, "Only offer commands typed in the current terminal session (needs aqs hook)")
+	quiet := flag.Bool("q", false, "Quiet: don't echo the selected command or the Running: banner")
+	flag.BoolVar(quiet, "quiet", false, "Quiet: don't echo the selected command or the Running: banner")
 	showVersion := flag.Bool("v", false, "Show version")
 	flag.BoolVar(showVersion, "version", false, "Show version")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "AQS — fuzzy search recent commands\n\n")
-		fmt.Fprintf(os.Stderr, "Usage: aqs [options] [query]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: aqs [options] [query] [-- extra args]\n")
+		fmt.Fprintf(os.Stderr, "       aqs bookmark [add|list|rm]\n")
+		fmt.Fprintf(os.Stderr, "       aqs prune\n")
+		fmt.Fprintf(os.Stderr, "       aqs aliases [--shell bash|zsh|fish]\n")
+		fmt.Fprintf(os.Stderr, "       aqs hook bash|zsh|fish\n\n")
 		fmt.Fprintf(os.Stderr, "Opens fzf picker and executes the selected command.\n")
 		fmt.Fprintf(os.Stderr, "Use -d/--dry-run to only print without executing.\n")
+		fmt.Fprintf(os.Stderr, "Use -1/--select-first to skip the picker on a single confident match.\n")
+		fmt.Fprintf(os.Stderr, "Use -q/--quiet to keep AQS's own output out of pipelines.\n")
+		fmt.Fprintf(os.Stderr, "Without a terminal, prints the top match instead (see --force-exec).\n")
 		fmt.Fprintf(os.Stderr, "Use -a/--add to add a command to the AQC file.\n")
 		fmt.Fprintf(os.Stderr, "Use -v/--version to show version.\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 	}
-	flag.Parse()
+	// Everything after "--" is passed through to the selected command
+	args, extraArgs := splitPassThrough(os.Args[1:])
+	flag.CommandLine.Parse(args)
 
 	// Handle -v flag: show version
 	if *showVersion {
@@ -70,34 +109,154 @@ func main() {
 		query = strings.Join(flag.Args(), " ")
 	}
 
+	// Commands saved in the current directory's AQC file come first, unless
+	// only this session's commands are wanted
+	var aqcEntries []aqcEntry
 	paths := detectHistoryPaths()
-	items := readHistory(paths)
+	if *session {
+		p, err := sessionHistoryPath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitNoHistory)
+		}
+		paths = []string{p}
+	} else if cwd, err := os.Getwd(); err == nil {
+		aqcEntries = readAQC(cwd)
+	}
+
+	history := readHistory(paths, !*noDedupe)
+	items := mergeAQC(aqcEntries, history, !*noDedupe)
 	if len(items) == 0 {
 		fmt.Fprintln(os.Stderr, "No history found.")
-		os.Exit(2)
+		os.Exit(exitNoHistory)
+	}
+
+	// Commands picked without showing the picker (-1, --first, no terminal)
+	// only come from history, so the AQC file of a freshly cloned repository
+	// never runs on its own
+	top := ""
+	if len(history) > 0 {
+		top = history[0]
 	}
 
 	// If query provided, pre-sort by similarity
+	selected := ""
 	if query != "" {
-		items = sortBySimilarity(query, items)
+		items = itemsOf(rankBySimilarity(query, items))
+		ranked := rankBySimilarity(query, history)
+		top = topMatch(ranked)
+		if *selectFirst {
+			selected = confidentMatch(ranked)
+		}
+	}
+	if *first {
+		if top == "" {
+			exitWithoutMatch(query)
+		}
+		selected = top
 	}
 
-	// Open fzf interactive picker
-	selected := callFzf(items, query, query != "")
+	// Without a terminal (cron, CI, pipes) the picker cannot run: fall back
+	// to the top match, and only print whatever was picked, including by
+	// -1 or --first, unless --force-exec is given
+	if !isInteractive() {
+		if selected == "" {
+			if top == "" {
+				exitWithoutMatch(query)
+			}
+			selected = top
+		}
+		if !*forceExec {
+			*dryRun = true
+			if !*quiet {
+				fmt.Fprintln(os.Stderr, "No terminal attached; printing the command instead of running it (use --force-exec to run).")
+			}
+		}
+	}
+
+	// Open fzf interactive picker unless a command was already picked
+	if selected == "" {
+		var sig os.Signal
+		selected, sig = callFzf(items, query, query != "")
+		if sig != nil {
+			os.Exit(exitCodeForSignal(sig))
+		}
+	}
 	if selected == "" {
 		if _, err := exec.LookPath("fzf"); err != nil {
 			fmt.Fprintln(os.Stderr, "fzf not found. Install fzf: brew install fzf")
 		}
-		os.Exit(1)
+		os.Exit(exitNoSelection)
 	}
 
-	// Print selected command
-	fmt.Println(selected)
+	command := appendArgs(selected, extraArgs)
+
+	// Print selected command; quiet mode only prints it for a dry run
+	if !*quiet || *dryRun {
+		fmt.Println(command)
+	}
 
 	// Execute unless dry-run
 	if !*dryRun {
-		os.Exit(runCommand(selected))
+		if needsConfirmation(aqcEntries, selected) && !confirmCommand(command) {
+			fmt.Fprintln(os.Stderr, "Aborted.")
+			os.Exit(exitNoSelection)
+		}
+		os.Exit(runCommand(command, "", *quiet))
+	}
+}
+
+// exitWithoutMatch reports that no history command can be picked for query
+// without the picker and exits with exitNoMatch.
+func exitWithoutMatch(query string) {
+	if query == "" {
+		fmt.Fprintln(os.Stderr, "No history command to pick.")
+	} else {
+		fmt.Fprintf(os.Stderr, "No command matches %q.\n", query)
+	}
+	os.Exit(exitNoMatch)
+}
+
+// splitPassThrough splits args at the first "--" into AQS's own arguments
+// and the extra arguments to append to the selected command.
+func splitPassThrough(args []string) ([]string, []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// appendArgs appends extra arguments to cmd, quoting them for the shell.
+func appendArgs(cmd string, extra []string) string {
+	if len(extra) == 0 {
+		return cmd
+	}
+	quoted := make([]string, len(extra))
+	for i, arg := range extra {
+		quoted[i] = shellQuote(arg)
 	}
+	return cmd + " " + strings.Join(quoted, " ")
+}
+
+// shellQuote returns s unchanged if it is safe to pass to a POSIX shell as
+// a single word, and single-quoted otherwise.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,+@%", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 func detectHistoryPaths() []string {
@@ -113,76 +272,228 @@ func detectHistoryPaths() []string {
 	}
 }
 
-func readHistory(paths []string) []string {
-	var cmds []string
+// historyEntry is a command read from a history file and the Unix time it
+// was run at.
+type historyEntry struct {
+	cmd  string
+	when int64
+}
 
-	for _, p := range paths {
-		file, err := os.Open(p)
-		if err != nil {
-			continue
-		}
+// readHistory returns the most recent commands from paths, newest first.
+// With dedupe, only the most recent occurrence of each command is kept;
+// otherwise every occurrence is returned in the order it was run, the
+// files merged by the timestamps they record.
+func readHistory(paths []string, dedupe bool) []string {
+	var entries []historyEntry
+	for i := len(paths) - 1; i >= 0; i-- {
+		entries = append(entries, readHistoryFile(paths[i], maxLines)...)
+	}
 
-		isFish := strings.Contains(p, "fish_history")
-		scanner := bufio.NewScanner(file)
-		// Increase buffer size for long lines
-		buf := make([]byte, 0, 64*1024)
-		scanner.Buffer(buf, 1024*1024)
-
-		for scanner.Scan() {
-			line := scanner.Text()
-			if isFish {
-				// fish history: lines like "- cmd: git status"
-				line = strings.TrimSpace(line)
-				if strings.HasPrefix(line, "- cmd:") {
-					cmd := strings.TrimSpace(strings.TrimPrefix(line, "- cmd:"))
-					if cmd != "" {
-						cmds = append(cmds, cmd)
-					}
-				}
-			} else {
-				// Handle zsh extended history format: ": timestamp:0;command"
-				if strings.HasPrefix(line, ": ") && strings.Contains(line, ";") {
-					idx := strings.Index(line, ";")
-					if idx != -1 {
-						line = line[idx+1:]
-					}
-				}
-				line = strings.TrimSpace(line)
-				if line != "" {
-					cmds = append(cmds, line)
-				}
-			}
-		}
-		file.Close()
+	if !dedupe {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].when > entries[j].when
+		})
 	}
 
-	// Keep only the last maxLines entries
-	if len(cmds) > maxLines {
-		cmds = cmds[len(cmds)-maxLines:]
+	// Keep only the most recent maxLines entries
+	if len(entries) > maxLines {
+		entries = entries[:maxLines]
 	}
 
-	// Dedupe preserving most recent — iterate reversed and keep first occurrences
+	if !dedupe {
+		all := make([]string, len(entries))
+		for i, e := range entries {
+			all[i] = e.cmd
+		}
+		return all
+	}
+
+	// Dedupe preserving most recent — keep first occurrences
 	seen := make(map[string]bool)
 	var uniq []string
-	for i := len(cmds) - 1; i >= 0; i-- {
-		cmd := cmds[i]
-		if seen[cmd] {
+	for _, e := range entries {
+		if seen[e.cmd] {
 			continue
 		}
-		seen[cmd] = true
-		uniq = append(uniq, cmd)
+		seen[e.cmd] = true
+		uniq = append(uniq, e.cmd)
 	}
 
 	return uniq
 }
 
+// readHistoryFile returns up to limit of the most recent commands in the
+// history file at p, newest first. The file is read from the end so memory
+// stays bounded however large it is. A command without a timestamp gets
+// the one of the next newer command, or counts as newest when there is
+// none, so sorting by time keeps the file's own order.
+func readHistoryFile(p string, limit int) []historyEntry {
+	var entries []historyEntry
+	when := int64(math.MaxInt64)
+	scanHistoryFile(p, func(cmd string, t int64) bool {
+		if t != 0 && t < when {
+			when = t
+		}
+		entries = append(entries, historyEntry{cmd, when})
+		return len(entries) < limit
+	})
+	return entries
+}
+
+// scanHistoryFile calls fn with each command in the history file at p,
+// newest first, until fn returns false. fn also gets the time the command
+// was run, or 0 when the file does not record it: bash stores it on a
+// "#1700000000" line before the command, zsh's extended history in front
+// of it and fish in the "when:" field after it.
+func scanHistoryFile(p string, fn func(cmd string, when int64) bool) {
+	file, err := os.Open(p)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	isFish := strings.Contains(p, "fish_history")
+	isZsh := strings.Contains(p, "zsh_history")
+
+	var fishWhen int64 // "when:" of the next "- cmd:" line up
+	pending := ""      // command waiting to see if a timestamp line precedes it
+	flush := func(when int64) bool {
+		cmd := pending
+		pending = ""
+		return cmd == "" || fn(cmd, when)
+	}
+
+	tailLines(file, func(raw []byte) bool {
+		if isZsh {
+			raw = unmetafy(raw)
+		}
+		line := sanitizeLine(raw)
+		if !isFish && isTimestampLine(raw) {
+			return flush(historyTime(line))
+		}
+
+		cmd := parseHistoryLine(line, isFish)
+		switch {
+		case isFish && cmd == "":
+			if t := historyTime(line); t != 0 {
+				fishWhen = t
+			}
+			return true
+		case isFish:
+			when := fishWhen
+			fishWhen = 0
+			return fn(cmd, when)
+		case cmd == "":
+			return true
+		case isZsh:
+			return fn(cmd, historyTime(line))
+		}
+		if !flush(0) {
+			return false
+		}
+		pending = cmd
+		return true
+	})
+	flush(0)
+}
+
+// historyTime parses the Unix time on a history line: a bash "#1700000000"
+// line, a fish "when: 1700000000" field or the start of a zsh extended
+// history entry (": 1700000000:0;command"). It returns 0 if there is none.
+func historyTime(line string) int64 {
+	line = strings.TrimSpace(line)
+	var digits string
+	switch {
+	case strings.HasPrefix(line, "#"):
+		digits = line[1:]
+	case strings.HasPrefix(line, "when:"):
+		digits = strings.TrimSpace(strings.TrimPrefix(line, "when:"))
+	case strings.HasPrefix(line, ": "):
+		digits, _, _ = strings.Cut(strings.TrimSpace(line[2:]), ":")
+	}
+	t, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || t < 0 {
+		return 0
+	}
+	return t
+}
+
+// parseHistoryLine extracts the command from a single history line, or
+// returns "" if the line does not hold one.
+func parseHistoryLine(line string, isFish bool) string {
+	if isFish {
+		// fish history: lines like "- cmd: git status"
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "- cmd:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "- cmd:"))
+		}
+		return ""
+	}
+
+	// Handle zsh extended history format: ": timestamp:0;command"
+	if strings.HasPrefix(line, ": ") && strings.Contains(line, ";") {
+		idx := strings.Index(line, ";")
+		if idx != -1 {
+			line = line[idx+1:]
+		}
+	}
+	return strings.TrimSpace(line)
+}
+
 type scoredItem struct {
-	item   string
-	score1 int // primary score (higher = better)
-	score2 int // secondary score (lower = better, typically length)
+	item      string
+	matched   bool // query is a substring or fuzzy match of item
+	substring bool // query is a substring of item
+	score1    int  // primary score (higher = better)
+	score2    int  // secondary score (lower = better, typically length)
+}
+
+// confidentScore is the minimum similarity score for -1/--select-first to
+// accept a match without opening the picker: the query has to appear as a
+// whole word or better.
+const confidentScore = 700
+
+// confidentMatch returns the only ranked item scoring at least
+// confidentScore, or "" when there are none or several. Repeats of one
+// command, as kept by --no-dedupe, count as a single match.
+func confidentMatch(scored []scoredItem) string {
+	match := ""
+	for _, s := range scored {
+		if s.score1 < confidentScore {
+			break
+		}
+		if match != "" && s.item != match {
+			return ""
+		}
+		match = s.item
+	}
+	return match
+}
+
+// topMatch returns the best ranked item that contains the query or scores
+// at least confidentScore, which is what --first and runs without a
+// terminal pick. Fuzzy-only matches do not count, so "" is returned when
+// they are all there is.
+func topMatch(scored []scoredItem) string {
+	for _, s := range scored {
+		if s.substring || s.score1 >= confidentScore {
+			return s.item
+		}
+	}
+	return ""
+}
+
+// itemsOf returns the items of scored in order.
+func itemsOf(scored []scoredItem) []string {
+	items := make([]string, len(scored))
+	for i, s := range scored {
+		items[i] = s.item
+	}
+	return items
 }
 
-func sortBySimilarity(query string, items []string) []string {
+// rankBySimilarity scores items against query and returns them best first.
+func rankBySimilarity(query string, items []string) []scoredItem {
 	queryLower := strings.ToLower(query)
 
 	scored := make([]scoredItem, len(items))
@@ -193,6 +504,9 @@ func sortBySimilarity(query string, items []string) []string {
 			score2: len(item),
 		}
 		itemLower := strings.ToLower(item)
+		// Every rule below but the fuzzy fallback needs a substring match
+		scored[i].substring = strings.Contains(itemLower, queryLower)
+		scored[i].matched = scored[i].substring
 
 		// Exact match gets highest score
 		if itemLower == queryLower {
@@ -251,32 +565,36 @@ func sortBySimilarity(query string, items []string) []string {
 		// Fuzzy match fallback using sahilm/fuzzy
 		matches := fuzzy.Find(queryLower, []string{itemLower})
 		if len(matches) > 0 {
+			scored[i].matched = true
 			scored[i].score1 = matches[0].Score
 		}
 	}
 
-	// Sort by score descending, then by length ascending
-	sort.Slice(scored, func(i, j int) bool {
+	// Sort matches first (fuzzy scores can be negative), then by score
+	// descending, then by length ascending. The sort is stable so ties
+	// keep their recency order.
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].matched != scored[j].matched {
+			return scored[i].matched
+		}
 		if scored[i].score1 != scored[j].score1 {
 			return scored[i].score1 > scored[j].score1
 		}
 		return scored[i].score2 < scored[j].score2
 	})
 
-	result := make([]string, len(scored))
-	for i, s := range scored {
-		result[i] = s.item
-	}
-	return result
+	return scored
 }
 
-func callFzf(items []string, initialQuery string, useCustomSort bool) string {
-	fzfPath, err := exec.LookPath("fzf")
-	if err != nil {
-		return ""
-	}
+// fzfAbortCode is fzf's exit status when the picker is closed with
+// Ctrl-C or Esc. fzf reads both as keys and reports them alike, so either
+// makes AQS exit 130 as if interrupted.
+const fzfAbortCode = 130
 
-	args := []string{"--ansi", "--reverse", "--tiebreak=index"}
+// callFzf opens the picker and returns the selected item. The returned
+// signal is non-nil when the picker was interrupted or aborted.
+func callFzf(items []string, initialQuery string, useCustomSort bool) (string, os.Signal) {
+	var args []string
 	if useCustomSort {
 		args = append(args, "--no-sort")
 	}
@@ -284,44 +602,63 @@ func callFzf(items []string, initialQuery string, useCustomSort bool) string {
 		args = append(args, "--query", initialQuery)
 	}
 
-	cmd := exec.Command(fzfPath, args...)
-	cmd.Stderr = os.Stderr
-
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return ""
+	selected, sig := runFzf(items, args...)
+	if len(selected) == 0 {
+		return "", sig
 	}
+	return selected[0], sig
+}
 
-	stdout, err := cmd.StdoutPipe()
+// runFzf feeds items to fzf with the given extra arguments and returns the
+// selected lines. It returns nil when fzf is not installed.
+func runFzf(items []string, extraArgs ...string) ([]string, os.Signal) {
+	fzfPath, err := exec.LookPath("fzf")
 	if err != nil {
-		return ""
+		return nil, nil
 	}
 
-	if err := cmd.Start(); err != nil {
-		return ""
+	args := append([]string{"--ansi", "--reverse", "--tiebreak=index"}, extraArgs...)
+
+	var input strings.Builder
+	for _, item := range items {
+		input.WriteString(item)
+		input.WriteByte('\n')
 	}
 
-	// Write items to fzf stdin
-	go func() {
-		for _, item := range items {
-			fmt.Fprintln(stdin, item)
-		}
-		stdin.Close()
-	}()
+	var output strings.Builder
+	cmd := exec.Command(fzfPath, args...)
+	cmd.Stdin = strings.NewReader(input.String())
+	cmd.Stdout = &output
+	cmd.Stderr = os.Stderr
 
-	// Read selected item
-	scanner := bufio.NewScanner(stdout)
-	var selected string
-	if scanner.Scan() {
-		selected = scanner.Text()
+	sig, code, _ := runSupervised(cmd)
+	if sig != nil {
+		return nil, sig
+	}
+	if code == fzfAbortCode {
+		return nil, os.Interrupt
 	}
 
-	cmd.Wait()
-	return strings.TrimSpace(selected)
+	var selected []string
+	for _, line := range strings.Split(output.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			selected = append(selected, line)
+		}
+	}
+	return selected, nil
 }
 
-func runCommand(cmd string) int {
-	fmt.Fprintf(os.Stderr, "Running: %s\n", cmd)
+// runCommand runs cmd through the user's shell in dir, or in the current
+// directory when dir is empty, and returns its exit code. Unless quiet, the
+// command is announced on stderr first.
+func runCommand(cmd, dir string, quiet bool) int {
+	switch {
+	case quiet:
+	case dir != "":
+		fmt.Fprintf(os.Stderr, "Running: %s (in %s)\n", cmd, dir)
+	default:
+		fmt.Fprintf(os.Stderr, "Running: %s\n", cmd)
+	}
 
 	// Detect shell
 	shell := os.Getenv("SHELL")
@@ -330,18 +667,20 @@ func runCommand(cmd string) int {
 	}
 
 	proc := exec.Command(shell, "-c", cmd)
+	proc.Dir = dir
 	proc.Stdin = os.Stdin
 	proc.Stdout = os.Stdout
 	proc.Stderr = os.Stderr
 
-	if err := proc.Run(); err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return exitErr.ExitCode()
-		}
+	sig, code, err := runSupervised(proc)
+	if sig != nil {
+		return exitCodeForSignal(sig)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running command: %v\n", err)
 		return 1
 	}
-	return 0
+	return code
 }
 
 func readLine(reader *bufio.Reader, prompt string) string {
@@ -350,6 +689,21 @@ func readLine(reader *bufio.Reader, prompt string) string {
 	return strings.TrimSpace(line)
 }
 
+// askYesNo prints prompt to stderr and reads the answer from the terminal,
+// falling back to stdin. Only "y" or "yes" count as consent.
+func askYesNo(prompt string) bool {
+	in := os.Stdin
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		defer tty.Close()
+		in = tty
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
 func addCommandToAQC() {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -361,14 +715,17 @@ func addCommandToAQC() {
 
 	// Get history and let user select a command
 	paths := detectHistoryPaths()
-	items := readHistory(paths)
+	items := readHistory(paths, true)
 	if len(items) == 0 {
 		fmt.Fprintln(os.Stderr, "No history found.")
-		os.Exit(2)
+		os.Exit(exitNoHistory)
 	}
 
 	fmt.Fprintln(os.Stderr, "Select a command to add to AQC:")
-	selected := callFzf(items, "", false)
+	selected, sig := callFzf(items, "", false)
+	if sig != nil {
+		os.Exit(exitCodeForSignal(sig))
+	}
 	if selected == "" {
 		if _, err := exec.LookPath("fzf"); err != nil {
 			fmt.Fprintln(os.Stderr, "fzf not found. Install fzf: brew install fzf")
@@ -389,18 +746,23 @@ func addCommandToAQC() {
 	}
 
 	desc := readLine(reader, "Description (optional): ")
+	confirm := strings.ToLower(readLine(reader, "Require confirmation before running? [y/N]: "))
 
 	// Format the entry
 	var entry string
 	if desc != "" {
-		entry = fmt.Sprintf("%s\n- %s: %s\n---\n", selected, name, desc)
+		entry = fmt.Sprintf("%s\n- %s: %s\n", selected, name, desc)
 	} else {
-		entry = fmt.Sprintf("%s\n- %s\n---\n", selected, name)
+		entry = fmt.Sprintf("%s\n- %s\n", selected, name)
+	}
+	if confirm == "y" || confirm == "yes" {
+		entry += "confirm: true\n"
 	}
+	entry += "---\n"
 
 	// Check if file exists, create with header if not
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		header := "# AQC Command File\n# Format:\n# command\n# - Name: Description\n# ---\n\n"
+		header := "# AQC Command File\n# Format:\n# command\n# - Name: Description\n# confirm: true (optional)\n# ---\n\n"
 		err = os.WriteFile(filePath, []byte(header+entry), 0644)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating AQC file: %v\n", err)
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadHistory(t *testing.T) {
+	files := map[string]string{
+		".bash_history": "#100\nmake\n#300\ngit pull\n#500\nmake\n",
+		".zsh_history":  ": 200:0;ls\n: 400:0;cd src\n",
+		"fish_history":  "- cmd: vim main.go\n  when: 250\n- cmd: make test\n  when: 600\n  paths:\n    - test\n",
+		"untimed":       "echo one\necho two\n",
+		"mixed":         "old\n#300\ntimed\nnewer\n",
+	}
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	for name, content := range files {
+		if err := os.WriteFile(path(name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	shells := []string{path(".bash_history"), path(".zsh_history"), path("fish_history")}
+
+	tests := []struct {
+		name   string
+		paths  []string
+		dedupe bool
+		want   []string
+	}{
+		{
+			name:  "no-dedupe merges files by time",
+			paths: shells,
+			want:  []string{"make test", "make", "cd src", "git pull", "vim main.go", "ls", "make"},
+		},
+		{
+			name:   "dedupe lists later files first",
+			paths:  shells,
+			dedupe: true,
+			want:   []string{"make test", "vim main.go", "cd src", "ls", "make", "git pull"},
+		},
+		{
+			name:  "untimed file keeps its order ahead of timed ones",
+			paths: []string{path("untimed"), path(".zsh_history")},
+			want:  []string{"echo two", "echo one", "cd src", "ls"},
+		},
+		{
+			name:  "untimed entries stay in place within a file",
+			paths: []string{path("mixed"), path(".zsh_history")},
+			want:  []string{"newer", "cd src", "timed", "old", "ls"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readHistory(tt.paths, tt.dedupe); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readHistory = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryTime(t *testing.T) {
+	tests := []struct {
+		line string
+		want int64
+	}{
+		{"#1700000000", 1700000000},
+		{"  when: 1700000001", 1700000001},
+		{": 1700000002:0;git status", 1700000002},
+		{": 1700000003:12;make", 1700000003},
+		{"git status", 0},
+		{"# a comment", 0},
+		{"- cmd: ls", 0},
+	}
+	for _, tt := range tests {
+		if got := historyTime(tt.line); got != tt.want {
+			t.Errorf("historyTime(%q) = %d, want %d", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestTopMatch(t *testing.T) {
+	long := "echo " + strings.Repeat("x", 600) + "rm"
+	tests := []struct {
+		name    string
+		query   string
+		history []string
+		want    string
+	}{
+		{"substring", "make", []string{"ls", "make test"}, "make test"},
+		{"fuzzy only", "rm", []string{"git remote add origin url", "ls"}, ""},
+		{"substring ranked below fuzzy", "rm", []string{"git remote add origin url", long}, long},
+		{"empty history", "rm", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := topMatch(rankBySimilarity(tt.query, tt.history)); got != tt.want {
+				t.Errorf("topMatch = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfidentMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		history []string
+		want    string
+	}{
+		{"single", "deploy", []string{"ls", "make deploy"}, "make deploy"},
+		{"repeats count once", "deploy", []string{"make deploy", "ls", "make deploy"}, "make deploy"},
+		{"several", "make", []string{"make build", "make test"}, ""},
+		{"substring is not confident", "dep", []string{"make deploy"}, ""},
+		{"none", "deploy", []string{"ls"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := confidentMatch(rankBySimilarity(tt.query, tt.history)); got != tt.want {
+				t.Errorf("confidentMatch = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
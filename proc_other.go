@@ -0,0 +1,31 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup is a no-op where process groups are not available; the
+// child always shares AQS's console.
+func setProcessGroup(cmd *exec.Cmd) (*os.File, bool) { return nil, false }
+
+// waitProcessGroup waits for the child and returns its wait status.
+func waitProcessGroup(cmd *exec.Cmd, tty *os.File) (syscall.WaitStatus, error) {
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		ws, _ := exitErr.Sys().(syscall.WaitStatus)
+		return ws, nil
+	}
+	return syscall.WaitStatus{}, err
+}
+
+// signalProcessGroup kills the child; other signals cannot be delivered
+// on this platform.
+func signalProcessGroup(p *os.Process, sig os.Signal) {
+	p.Kill()
+}
@@ -0,0 +1,140 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// setProcessGroup puts the child in its own process group so the whole
+// pipeline it spawns can be signalled at once. When AQS holds the
+// terminal's foreground, the child's group is handed the terminal in its
+// place: Ctrl-C and Ctrl-Z then reach the child directly, and its /dev/tty
+// reads (fzf's always) are not stopped with SIGTTIN even if AQS's stdin is
+// redirected. It returns the terminal the child was handed, or nil, and
+// reports whether the child got a group of its own.
+func setProcessGroup(cmd *exec.Cmd) (*os.File, bool) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, true
+	}
+	if pgrp, err := foregroundGroup(tty); err != nil || pgrp != syscall.Getpgrp() {
+		tty.Close()
+		return nil, true
+	}
+	cmd.SysProcAttr.Foreground = true
+	cmd.SysProcAttr.Ctty = int(tty.Fd())
+	return tty, true
+}
+
+// waitProcessGroup waits for a child started after setProcessGroup and
+// returns its wait status. If a child holding the terminal is stopped,
+// typically with Ctrl-Z, AQS takes the terminal back and stops its own
+// group, so the shell regains control as it would for any job. Once AQS
+// is continued it continues the child, handing it the terminal again if
+// AQS was resumed in the foreground. The terminal is returned to AQS when
+// the child exits.
+func waitProcessGroup(cmd *exec.Cmd, tty *os.File) (syscall.WaitStatus, error) {
+	// The child is reaped below; Wait then only collects its output.
+	defer cmd.Wait()
+
+	options := 0
+	if tty != nil {
+		defer tty.Close()
+		options = syscall.WUNTRACED
+	}
+	childHasTerminal := tty != nil
+
+	pid := cmd.Process.Pid
+	var ws syscall.WaitStatus
+	for {
+		_, err := syscall.Wait4(pid, &ws, options, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return ws, err
+		}
+		if !ws.Stopped() {
+			break
+		}
+
+		setForegroundGroup(tty, syscall.Getpgrp())
+		suspend()
+		pgrp, err := foregroundGroup(tty)
+		childHasTerminal = err == nil && pgrp == syscall.Getpgrp()
+		if childHasTerminal {
+			setForegroundGroup(tty, pid)
+		}
+		syscall.Kill(-pid, syscall.SIGCONT)
+	}
+
+	if childHasTerminal {
+		setForegroundGroup(tty, syscall.Getpgrp())
+	}
+	return ws, nil
+}
+
+// suspend stops AQS's process group as Ctrl-Z would and returns once AQS
+// is continued. The stop is asynchronous, so AQS waits for its SIGCONT
+// rather than for kill to return.
+func suspend() {
+	if signal.Ignored(syscall.SIGTSTP) {
+		return
+	}
+	cont := make(chan os.Signal, 1)
+	signal.Notify(cont, syscall.SIGCONT)
+	defer signal.Stop(cont)
+
+	syscall.Kill(0, syscall.SIGTSTP)
+	<-cont
+}
+
+// foregroundGroup returns the terminal's foreground process group.
+func foregroundGroup(tty *os.File) (int, error) {
+	var pgrp int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, tty.Fd(), uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp))); errno != 0 {
+		return 0, errno
+	}
+	return int(pgrp), nil
+}
+
+// ttouSignals receives the SIGTTOU that setForegroundGroup stops ignoring.
+var ttouSignals = make(chan os.Signal, 1)
+
+// setForegroundGroup makes pgrp the terminal's foreground process group.
+// AQS is in the background when it takes the terminal back, which only
+// works with SIGTTOU ignored. Afterwards the signal is caught and dropped
+// rather than left ignored, since children would inherit an ignored
+// disposition.
+func setForegroundGroup(tty *os.File, pgrp int) {
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Notify(ttouSignals, syscall.SIGTTOU)
+
+	p := int32(pgrp)
+	syscall.Syscall(syscall.SYS_IOCTL, tty.Fd(), uintptr(syscall.TIOCSPGRP), uintptr(unsafe.Pointer(&p)))
+}
+
+// signalProcessGroup delivers sig to the child's process group when it has
+// one of its own, and to the child alone otherwise.
+func signalProcessGroup(p *os.Process, sig os.Signal) {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		p.Signal(sig)
+		return
+	}
+	if pgid, err := syscall.Getpgid(p.Pid); err == nil && pgid == p.Pid {
+		syscall.Kill(-pgid, s)
+		return
+	}
+	p.Signal(s)
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// commandPatterns returns the groups cmd belongs to in `aqs prune`: its
+// program ("cd", "git") and, when the next word looks like a subcommand,
+// the program together with that word ("git push"). Variable assignments
+// are reduced to the variable name ("export TOKEN=") so secrets group by
+// name rather than by value.
+func commandPatterns(cmd string) []string {
+	words := strings.Fields(cmd)
+	if len(words) == 0 {
+		return nil
+	}
+
+	first := patternWord(words[0])
+	patterns := []string{first}
+	if len(words) > 1 && isSubcommandWord(words[1]) {
+		patterns = append(patterns, first+" "+patternWord(words[1]))
+	}
+	return patterns
+}
+
+// patternWord strips the value from a NAME=value word.
+func patternWord(w string) string {
+	if i := strings.IndexByte(w, '='); i > 0 {
+		return w[:i+1]
+	}
+	return w
+}
+
+func isSubcommandWord(w string) bool {
+	if strings.IndexByte(w, '=') > 0 {
+		return true
+	}
+	for i, r := range w {
+		if i == 0 && !unicode.IsLetter(r) {
+			return false
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// isTimestampLine reports whether line is a bash HISTTIMEFORMAT marker
+// such as "#1700000000".
+func isTimestampLine(line []byte) bool {
+	line = bytes.TrimSpace(line)
+	if len(line) < 2 || line[0] != '#' {
+		return false
+	}
+	for _, c := range line[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// forEachEntry splits a history file into entries and calls fn with the
+// raw bytes of each one and the command it holds ("" for lines that are
+// not commands). An entry covers every line that belongs to the command:
+// a preceding bash timestamp, zsh backslash continuations or fish's
+// indented metadata.
+func forEachEntry(r io.Reader, isFish, isZsh bool, fn func(raw []byte, cmd string) error) error {
+	reader := bufio.NewReader(r)
+	var raw []byte
+	cmd := ""
+	flush := func() error {
+		if len(raw) == 0 {
+			return nil
+		}
+		err := fn(raw, cmd)
+		raw, cmd = nil, ""
+		return err
+	}
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			decoded := line
+			if isZsh {
+				decoded = unmetafy(decoded)
+			}
+			text := sanitizeLine(decoded)
+
+			switch {
+			case isFish:
+				if strings.HasPrefix(strings.TrimSpace(text), "- cmd:") {
+					if err := flush(); err != nil {
+						return err
+					}
+					cmd = parseHistoryLine(text, true)
+				}
+				raw = append(raw, line...)
+			case isZsh && len(raw) > 0 && bytes.HasSuffix(bytes.TrimRight(raw, "\r\n"), []byte{'\\'}):
+				raw = append(raw, line...)
+			case len(raw) > 0 && cmd == "" && isTimestampLine(raw):
+				raw = append(raw, line...)
+				cmd = parseHistoryLine(text, false)
+			default:
+				if err := flush(); err != nil {
+					return err
+				}
+				raw = append(raw, line...)
+				if !isTimestampLine(line) {
+					cmd = parseHistoryLine(text, false)
+				}
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	return flush()
+}
+
+// openHistoryForPrune opens a history file positioned after its byte order
+// mark, which it returns. Only UTF-8 files can be rewritten.
+func openHistoryForPrune(p string) (*os.File, []byte, error) {
+	file, err := os.Open(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc, start := detectEncoding(file)
+	if enc != encodingUTF8 {
+		file.Close()
+		return nil, nil, fmt.Errorf("UTF-16 history files cannot be pruned")
+	}
+	bom := make([]byte, start)
+	if _, err := io.ReadFull(file, bom); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, bom, nil
+}
+
+// countPatterns tallies how many entries of each pattern the history files
+// hold. Unlike searching, pruning looks at the whole file.
+func countPatterns(paths []string) map[string]int {
+	counts := make(map[string]int)
+	for _, p := range paths {
+		file, _, err := openHistoryForPrune(p)
+		if err != nil {
+			continue
+		}
+		forEachEntry(file, strings.Contains(p, "fish_history"), strings.Contains(p, "zsh_history"), func(raw []byte, cmd string) error {
+			for _, pattern := range commandPatterns(cmd) {
+				counts[pattern]++
+			}
+			return nil
+		})
+		file.Close()
+	}
+	return counts
+}
+
+// matchesAny reports whether cmd belongs to one of the selected patterns.
+func matchesAny(cmd string, selected map[string]bool) bool {
+	for _, pattern := range commandPatterns(cmd) {
+		if selected[pattern] {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneFile removes the entries matching selected from the history file at
+// p. Unless dryRun, the original is first copied to a timestamped backup,
+// whose path is returned, and the file is then replaced atomically. When p
+// is a symlink, as dotfile managers set up, the file it points to is
+// replaced and the link kept.
+func pruneFile(p string, selected map[string]bool, dryRun bool) (int, string, error) {
+	isFish := strings.Contains(p, "fish_history")
+	isZsh := strings.Contains(p, "zsh_history")
+
+	file, bom, err := openHistoryForPrune(p)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	removed := 0
+	forEachEntry(file, isFish, isZsh, func(raw []byte, cmd string) error {
+		if matchesAny(cmd, selected) {
+			removed++
+		}
+		return nil
+	})
+	if removed == 0 || dryRun {
+		return removed, "", nil
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+
+	backup := p + ".aqs-backup-" + time.Now().Format("20060102-150405")
+	if err := copyFile(file, backup, info.Mode()); err != nil {
+		return 0, "", fmt.Errorf("writing backup: %w", err)
+	}
+
+	target, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return 0, backup, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".aqs-prune-*")
+	if err != nil {
+		return 0, backup, err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	w.Write(bom)
+	if _, err := file.Seek(int64(len(bom)), io.SeekStart); err != nil {
+		tmp.Close()
+		return 0, backup, err
+	}
+	err = forEachEntry(file, isFish, isZsh, func(raw []byte, cmd string) error {
+		if matchesAny(cmd, selected) {
+			return nil
+		}
+		_, err := w.Write(raw)
+		return err
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Chmod(info.Mode())
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, backup, err
+	}
+
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return 0, backup, err
+	}
+	return removed, backup, nil
+}
+
+// copyFile writes the whole of src to a new file at dst.
+func copyFile(src *os.File, dst string, mode os.FileMode) error {
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// pruneCommand implements `aqs prune`.
+func pruneCommand(args []string) {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	dryRun := fs.Bool("d", false, "Dry run: show how many entries would be deleted without touching any file")
+	fs.BoolVar(dryRun, "dry-run", false, "Dry run: show how many entries would be deleted without touching any file")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: aqs prune [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Groups history by command pattern and deletes the groups you select\n")
+		fmt.Fprintf(os.Stderr, "from the history files. Each changed file is backed up first.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	paths := detectHistoryPaths()
+	counts := countPatterns(paths)
+	if len(counts) == 0 {
+		fmt.Fprintln(os.Stderr, "No history found.")
+		os.Exit(exitNoHistory)
+	}
+
+	patterns := make([]string, 0, len(counts))
+	for pattern := range counts {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if counts[patterns[i]] != counts[patterns[j]] {
+			return counts[patterns[i]] > counts[patterns[j]]
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	labels := make([]string, len(patterns))
+	byLabel := make(map[string]string, len(patterns))
+	for i, pattern := range patterns {
+		labels[i] = fmt.Sprintf("%6d  %s", counts[pattern], pattern)
+		byLabel[strings.TrimSpace(labels[i])] = pattern
+	}
+
+	picked, sig := runFzf(labels, "--multi", "--header", "TAB: mark groups to delete, ENTER: confirm")
+	if sig != nil {
+		os.Exit(exitCodeForSignal(sig))
+	}
+	if len(picked) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing selected.")
+		os.Exit(1)
+	}
+
+	selected := make(map[string]bool)
+	for _, label := range picked {
+		if pattern, ok := byLabel[label]; ok {
+			selected[pattern] = true
+		}
+	}
+
+	total := 0
+	for _, p := range paths {
+		n, _, err := pruneFile(p, selected, true)
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", p, err)
+			continue
+		}
+		if n > 0 {
+			fmt.Fprintf(os.Stderr, "%s: %d entries\n", p, n)
+			total += n
+		}
+	}
+	if total == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing to delete.")
+		return
+	}
+	if *dryRun {
+		fmt.Fprintf(os.Stderr, "Would delete %d entries.\n", total)
+		return
+	}
+	if !askYesNo(fmt.Sprintf("Delete %d entries? [y/N]: ", total)) {
+		fmt.Fprintln(os.Stderr, "Aborted.")
+		os.Exit(1)
+	}
+
+	for _, p := range paths {
+		n, backup, err := pruneFile(p, selected, false)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "Error pruning %s: %v\n", p, err)
+			}
+			continue
+		}
+		if n > 0 {
+			fmt.Printf("Deleted %d entries from %s (backup: %s)\n", n, p, backup)
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Running shells may write their in-memory history back; restart them to finish the cleanup.")
+}
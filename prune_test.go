@@ -0,0 +1,243 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testEntry struct {
+	raw string
+	cmd string
+}
+
+func TestForEachEntry(t *testing.T) {
+	tests := []struct {
+		name   string
+		isFish bool
+		isZsh  bool
+		input  string
+		want   []testEntry
+	}{
+		{
+			name:  "bash plain",
+			input: "ls\ngit status\n",
+			want: []testEntry{
+				{"ls\n", "ls"},
+				{"git status\n", "git status"},
+			},
+		},
+		{
+			name:  "bash timestamps",
+			input: "#1700000000\ncd src\n#1700000001\ngit push\n",
+			want: []testEntry{
+				{"#1700000000\ncd src\n", "cd src"},
+				{"#1700000001\ngit push\n", "git push"},
+			},
+		},
+		{
+			name:  "bash trailing backslash is not a continuation",
+			input: "#1\necho a \\\n#2\ncd src\n",
+			want: []testEntry{
+				{"#1\necho a \\\n", "echo a \\"},
+				{"#2\ncd src\n", "cd src"},
+			},
+		},
+		{
+			name:  "bash missing final newline",
+			input: "ls\nmake",
+			want: []testEntry{
+				{"ls\n", "ls"},
+				{"make", "make"},
+			},
+		},
+		{
+			name:  "zsh extended",
+			isZsh: true,
+			input: ": 1700000000:0;cd /tmp\n: 1700000001:0;ls\n",
+			want: []testEntry{
+				{": 1700000000:0;cd /tmp\n", "cd /tmp"},
+				{": 1700000001:0;ls\n", "ls"},
+			},
+		},
+		{
+			name:  "zsh multiline",
+			isZsh: true,
+			input: ": 1:0;echo multi \\\nline\n: 2:0;ls\n",
+			want: []testEntry{
+				{": 1:0;echo multi \\\nline\n", "echo multi \\"},
+				{": 2:0;ls\n", "ls"},
+			},
+		},
+		{
+			name:   "fish metadata",
+			isFish: true,
+			input:  "- cmd: cd foo\n  when: 1\n  paths:\n    - foo\n- cmd: make\n  when: 2\n",
+			want: []testEntry{
+				{"- cmd: cd foo\n  when: 1\n  paths:\n    - foo\n", "cd foo"},
+				{"- cmd: make\n  when: 2\n", "make"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []testEntry
+			err := forEachEntry(strings.NewReader(tt.input), tt.isFish, tt.isZsh, func(raw []byte, cmd string) error {
+				got = append(got, testEntry{string(raw), cmd})
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("forEachEntry: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		symlink  bool // file links to the history kept elsewhere
+		input    string
+		patterns []string
+		want     string
+		removed  int
+	}{
+		{
+			name:     "bash drops timestamps with their command",
+			file:     ".bash_history",
+			input:    "#1\ncd src\n#2\ngit status\n#3\ncd ..\n",
+			patterns: []string{"cd"},
+			want:     "#2\ngit status\n",
+			removed:  2,
+		},
+		{
+			name:     "bash backslash line keeps the next timestamp",
+			file:     ".bash_history",
+			input:    "#1\necho a \\\n#2\ncd src\n",
+			patterns: []string{"cd"},
+			want:     "#1\necho a \\\n",
+			removed:  1,
+		},
+		{
+			name:     "bash secrets by variable name",
+			file:     ".bash_history",
+			input:    "export TOKEN=abc\nexport PATH=/bin\n",
+			patterns: []string{"export TOKEN="},
+			want:     "export PATH=/bin\n",
+			removed:  1,
+		},
+		{
+			name:     "zsh multiline entry",
+			file:     ".zsh_history",
+			input:    ": 1:0;echo multi \\\nline\n: 2:0;ls\n",
+			patterns: []string{"echo"},
+			want:     ": 2:0;ls\n",
+			removed:  1,
+		},
+		{
+			name:     "fish entry with metadata",
+			file:     "fish_history",
+			input:    "- cmd: cd foo\n  when: 1\n  paths:\n    - foo\n- cmd: make\n  when: 2\n",
+			patterns: []string{"cd foo"},
+			want:     "- cmd: make\n  when: 2\n",
+			removed:  1,
+		},
+		{
+			name:     "utf-8 bom is kept",
+			file:     ".bash_history",
+			input:    "\xEF\xBB\xBFls\ncd src\n",
+			patterns: []string{"cd"},
+			want:     "\xEF\xBB\xBFls\n",
+			removed:  1,
+		},
+		{
+			name:     "symlinked history is pruned through the link",
+			file:     ".zsh_history",
+			symlink:  true,
+			input:    ": 1:0;echo multi \\\nline\n: 2:0;ls\n",
+			patterns: []string{"echo"},
+			want:     ": 2:0;ls\n",
+			removed:  1,
+		},
+		{
+			name:     "nothing selected leaves the file alone",
+			file:     ".bash_history",
+			input:    "ls\n",
+			patterns: []string{"cd"},
+			want:     "ls\n",
+			removed:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p := filepath.Join(dir, tt.file)
+			target := p
+			if tt.symlink {
+				target = filepath.Join(dir, "dotfiles", "history")
+				if err := os.Mkdir(filepath.Dir(target), 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Symlink(target, p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(target, []byte(tt.input), 0600); err != nil {
+				t.Fatal(err)
+			}
+			selected := make(map[string]bool)
+			for _, pattern := range tt.patterns {
+				selected[pattern] = true
+			}
+
+			n, _, err := pruneFile(p, selected, true)
+			if err != nil {
+				t.Fatalf("dry run: %v", err)
+			}
+			if n != tt.removed {
+				t.Errorf("dry run removed %d, want %d", n, tt.removed)
+			}
+
+			n, backup, err := pruneFile(p, selected, false)
+			if err != nil {
+				t.Fatalf("pruneFile: %v", err)
+			}
+			if n != tt.removed {
+				t.Errorf("removed %d, want %d", n, tt.removed)
+			}
+
+			got, err := os.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+			if info, err := os.Lstat(p); err != nil || (info.Mode()&os.ModeSymlink != 0) != tt.symlink {
+				t.Errorf("%s is no longer what it was (symlink: %v)", p, tt.symlink)
+			}
+
+			if tt.removed == 0 {
+				if backup != "" {
+					t.Errorf("backup %q written although nothing was removed", backup)
+				}
+				return
+			}
+			orig, err := os.ReadFile(backup)
+			if err != nil {
+				t.Fatalf("reading backup: %v", err)
+			}
+			if string(orig) != tt.input {
+				t.Errorf("backup = %q, want original %q", orig, tt.input)
+			}
+		})
+	}
+}
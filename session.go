@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sessionEnv holds the id the shell hook assigns to each interactive shell.
+const sessionEnv = "AQS_SESSION"
+
+// sessionMaxAge is how long session logs are kept before the hook clears
+// them out.
+const sessionMaxAge = 7 * 24 * time.Hour
+
+func sessionsDir() string {
+	dir := configDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "sessions")
+}
+
+// sessionHistoryPath returns the log the hook writes for the current
+// shell session.
+func sessionHistoryPath() (string, error) {
+	id := os.Getenv(sessionEnv)
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return "", fmt.Errorf("no shell session found; add the hook to your shell config: eval \"$(aqs hook bash|zsh)\" or aqs hook fish | source")
+	}
+	dir := sessionsDir()
+	if dir == "" {
+		return "", fmt.Errorf("cannot locate config directory")
+	}
+	return filepath.Join(dir, id), nil
+}
+
+// removeOldSessions deletes session logs that have not been written to for
+// sessionMaxAge.
+func removeOldSessions(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if time.Since(info.ModTime()) > sessionMaxAge {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
+// hookScript returns the snippet that gives a shell its session id and
+// logs every command it runs, one per line, for --session. Commands are
+// logged once they finish so a running `aqs --session` does not list
+// itself. The bash hook takes the history entry current at the first
+// prompt as its baseline, because bash loads $HISTFILE only after the rc
+// files have run, and preserves $? for the prompt commands that follow.
+func hookScript(shell, dir string) (string, error) {
+	switch shell {
+	case "bash":
+		return fmt.Sprintf(`export %[1]s="$$.$(date +%%s)"
+__aqs_log=%[2]s/"$%[1]s"
+__aqs_record() {
+  local ret=$? entry
+  entry=$(HISTTIMEFORMAT= builtin history 1)
+  if [ -z "${__aqs_last+set}" ]; then
+    __aqs_last=$entry
+  elif [ "$entry" != "$__aqs_last" ]; then
+    __aqs_last=$entry
+    entry=${entry#*[0-9]  }
+    [ -n "$entry" ] && printf '%%s\n' "${entry//$'\n'/ }" >> "$__aqs_log"
+  fi
+  return $ret
+}
+unset __aqs_last
+PROMPT_COMMAND="__aqs_record${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+`, sessionEnv, shellQuote(dir)), nil
+	case "zsh":
+		return fmt.Sprintf(`export %[1]s="$$.$(date +%%s)"
+typeset -g __aqs_log=%[2]s/"$%[1]s"
+__aqs_preexec() { __aqs_pending=$1 }
+__aqs_precmd() {
+  [ -n "$__aqs_pending" ] && print -r -- "${__aqs_pending//$'\n'/ }" >> "$__aqs_log"
+  __aqs_pending=
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec __aqs_preexec
+add-zsh-hook precmd __aqs_precmd
+`, sessionEnv, shellQuote(dir)), nil
+	case "fish":
+		return fmt.Sprintf(`set -gx %[1]s $fish_pid.(date +%%s)
+set -g __aqs_log %[2]s/$%[1]s
+function __aqs_postexec --on-event fish_postexec
+    string replace -a \n ' ' -- $argv[1] >> $__aqs_log
+end
+`, sessionEnv, fishQuote(dir)), nil
+	}
+	return "", fmt.Errorf("unsupported shell %q (want bash, zsh or fish)", shell)
+}
+
+// hookCommand implements `aqs hook <shell>`.
+func hookCommand(args []string) {
+	fs := flag.NewFlagSet("hook", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: aqs hook bash|zsh|fish\n\n")
+		fmt.Fprintf(os.Stderr, "Prints the shell hook that records each session's commands for --session.\n")
+		fmt.Fprintf(os.Stderr, "  bash/zsh: eval \"$(aqs hook zsh)\"\n")
+		fmt.Fprintf(os.Stderr, "  fish:     aqs hook fish | source\n")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	dir := sessionsDir()
+	if dir == "" {
+		fmt.Fprintln(os.Stderr, "Error: cannot locate config directory")
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating %s: %v\n", dir, err)
+		os.Exit(1)
+	}
+	removeOldSessions(dir)
+
+	script, err := hookScript(fs.Arg(0), dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	fmt.Print(script)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// interruptSignals are intercepted while fzf or the selected command is
+// running so AQS can tear the child down instead of leaving it orphaned.
+var interruptSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// killGracePeriod is how long a child gets to exit after being forwarded
+// a signal before it is killed outright.
+const killGracePeriod = 2 * time.Second
+
+// exitCodeForSignal follows the shell convention of 128 + signal number
+// (130 for SIGINT, 143 for SIGTERM).
+func exitCodeForSignal(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 130
+}
+
+// runSupervised starts cmd and waits for it. If AQS receives SIGINT or
+// SIGTERM, the signal is forwarded to the child's process group, which is
+// killed if it does not exit within killGracePeriod. Where the child
+// shares AQS's console and has no group of its own, it already receives
+// Ctrl-C itself, so AQS ignores SIGINT and lets the child decide how to
+// handle it. The terminal state is restored whenever the child was
+// interrupted. It returns the signal that interrupted the child, or nil if
+// it exited on its own, and the child's exit code.
+func runSupervised(cmd *exec.Cmd) (os.Signal, int, error) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, interruptSignals...)
+	defer signal.Stop(sigs)
+
+	term := saveTerminal()
+	tty, ownGroup := setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		if tty != nil {
+			tty.Close()
+		}
+		return nil, 0, err
+	}
+
+	type result struct {
+		ws  syscall.WaitStatus
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		ws, err := waitProcessGroup(cmd, tty)
+		done <- result{ws, err}
+	}()
+
+	var caught os.Signal
+	var res result
+wait:
+	for {
+		select {
+		case res = <-done:
+			break wait
+		case sig := <-sigs:
+			if sig == os.Interrupt && !ownGroup {
+				continue
+			}
+			caught = sig
+			signalProcessGroup(cmd.Process, sig)
+			select {
+			case res = <-done:
+			case <-time.After(killGracePeriod):
+				signalProcessGroup(cmd.Process, syscall.SIGKILL)
+				res = <-done
+			}
+			break wait
+		}
+	}
+
+	if caught == nil && res.ws.Signaled() {
+		caught = res.ws.Signal()
+	}
+	if caught != nil {
+		term.restore()
+	}
+	return caught, res.ws.ExitStatus(), res.err
+}
+
+// terminalState is an opaque `stty -g` snapshot of the controlling terminal.
+type terminalState string
+
+// saveTerminal snapshots the controlling terminal settings. It returns an
+// empty state when there is no terminal or stty is unavailable.
+func saveTerminal() terminalState {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return ""
+	}
+	defer tty.Close()
+
+	cmd := exec.Command("stty", "-g")
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return terminalState(strings.TrimSpace(string(out)))
+}
+
+// restore puts the terminal back the way saveTerminal found it, in case an
+// interrupted child (typically fzf) left it in raw mode.
+func (t terminalState) restore() {
+	if t == "" {
+		return
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return
+	}
+	defer tty.Close()
+
+	cmd := exec.Command("stty", string(t))
+	cmd.Stdin = tty
+	cmd.Run()
+}
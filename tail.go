@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// tailChunkSize is how much of a history file is read per step when
+// walking it backwards. It must be a multiple of every code unit size.
+const tailChunkSize = 64 * 1024
+
+// maxLineLen bounds a single history line; longer lines are skipped.
+const maxLineLen = 1024 * 1024
+
+// tailLines calls fn for each line of f, starting with the last one and
+// moving towards the beginning of the file, until fn returns false. Lines
+// are handed over as UTF-8 bytes (UTF-16 files, recognised by their byte
+// order mark, are transcoded) and are only valid for the duration of the
+// call. Only one chunk and the line being assembled are held in memory, so
+// the cost does not depend on the size of the file.
+func tailLines(f *os.File, fn func(line []byte) bool) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	enc, start := detectEncoding(f)
+	unit := enc.unitSize()
+	newline := enc.newline()
+
+	// Drop a trailing partial code unit so reads stay aligned
+	pos := start + (info.Size()-start)/int64(unit)*int64(unit)
+	chunk := make([]byte, tailChunkSize)
+	var partial []byte // start of the file-order line that ends at pos
+	skipping := false  // partial belongs to an over-long line being dropped
+
+	for pos > start {
+		n := int64(tailChunkSize)
+		if pos-start < n {
+			n = pos - start
+		}
+		pos -= n
+		if _, err := f.ReadAt(chunk[:n], pos); err != nil && err != io.EOF {
+			return err
+		}
+
+		buf := append(append([]byte(nil), chunk[:n]...), partial...)
+		for {
+			i := lastIndexAligned(buf, newline, unit)
+			if i == -1 {
+				break
+			}
+			line := buf[i+len(newline):]
+			if !skipping && len(line) <= maxLineLen && !fn(enc.toUTF8(line)) {
+				return nil
+			}
+			skipping = false
+			buf = buf[:i]
+		}
+
+		partial = buf
+		if len(partial) > maxLineLen {
+			partial = nil
+			skipping = true
+		}
+	}
+
+	if !skipping {
+		fn(enc.toUTF8(partial))
+	}
+	return nil
+}
+
+// lastIndexAligned is like bytes.LastIndex but only reports matches that
+// start on a code unit boundary.
+func lastIndexAligned(b, sep []byte, unit int) int {
+	end := len(b)
+	for {
+		i := bytes.LastIndex(b[:end], sep)
+		if i == -1 || i%unit == 0 {
+			return i
+		}
+		end = i + len(sep) - 1
+	}
+}
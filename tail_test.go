@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// numberedLines returns n lines "line 0" … "line n-1", each newline
+// terminated, and the same lines in the order tailLines reports them.
+func numberedLines(n int) (string, []string) {
+	var b strings.Builder
+	want := []string{""}
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	for i := n - 1; i >= 0; i-- {
+		want = append(want, fmt.Sprintf("line %d", i))
+	}
+	return b.String(), want
+}
+
+// utf16Text encodes code units as UTF-16 with a byte order mark.
+func utf16Text(units []uint16, bigEndian bool) string {
+	b := append([]byte(nil), bomUTF16LE...)
+	if bigEndian {
+		b = append([]byte(nil), bomUTF16BE...)
+	}
+	for _, u := range units {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return string(b)
+}
+
+func utf16LE(s string) string { return utf16Text(utf16.Encode([]rune(s)), false) }
+func utf16BE(s string) string { return utf16Text(utf16.Encode([]rune(s)), true) }
+
+func TestTailLines(t *testing.T) {
+	chunked, chunkedWant := numberedLines(3 * tailChunkSize / 8)
+	long := strings.Repeat("x", maxLineLen+1)
+
+	tests := []struct {
+		name    string
+		content string
+		limit   int // stop after this many lines; 0 reads them all
+		want    []string
+	}{
+		{
+			name:    "trailing newline",
+			content: "a\nb\n",
+			want:    []string{"", "b", "a"},
+		},
+		{
+			name:    "no trailing newline",
+			content: "a\nb",
+			want:    []string{"b", "a"},
+		},
+		{
+			name:    "empty file",
+			content: "",
+			want:    []string{""},
+		},
+		{
+			name:    "crlf keeps the carriage return",
+			content: "a\r\nb\r\n",
+			want:    []string{"", "b\r", "a\r"},
+		},
+		{
+			name:    "utf-8 bom",
+			content: "\xEF\xBB\xBFa\nb",
+			want:    []string{"b", "a"},
+		},
+		{
+			name:    "lines across chunk boundaries",
+			content: chunked,
+			want:    chunkedWant,
+		},
+		{
+			name:    "over-long line is skipped",
+			content: "first\n" + long + "\nlast",
+			want:    []string{"last", "first"},
+		},
+		{
+			name:    "over-long first line is skipped",
+			content: long + "\nlast",
+			want:    []string{"last"},
+		},
+		{
+			name:    "utf-16le",
+			content: utf16LE("a\nb"),
+			want:    []string{"b", "a"},
+		},
+		{
+			name:    "utf-16be",
+			content: utf16BE("a\nb\n"),
+			want:    []string{"", "b", "a"},
+		},
+		{
+			name:    "utf-16 crlf",
+			content: utf16LE("a\r\nb"),
+			want:    []string{"b", "a\r"},
+		},
+		{
+			// U+0A41 U+4E00 is 41 0A 00 4E in UTF-16LE: a newline
+			// straddling two code units must not split the line
+			name:    "utf-16 newline bytes across code units",
+			content: utf16LE("\u0A41\u4E00\nz"),
+			want:    []string{"z", "\u0A41\u4E00"},
+		},
+		{
+			name:    "utf-16 trailing partial unit is dropped",
+			content: utf16LE("a\nb") + "\x00",
+			want:    []string{"b", "a"},
+		},
+		{
+			name:    "utf-16 surrogate pair",
+			content: utf16BE("\U0001F600\nx"),
+			want:    []string{"x", "\U0001F600"},
+		},
+		{
+			name:    "utf-16 unpaired surrogate",
+			content: utf16Text([]uint16{0xD800, '\n', 'x'}, false),
+			want:    []string{"x", "\uFFFD"},
+		},
+		{
+			name:    "utf-16 lines across chunk boundaries",
+			content: utf16LE(chunked),
+			want:    chunkedWant,
+		},
+		{
+			name:    "stops when fn returns false",
+			content: "a\nb\nc\n",
+			limit:   2,
+			want:    []string{"", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "history")
+			if err := os.WriteFile(p, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.Open(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			var got []string
+			err = tailLines(f, func(line []byte) bool {
+				got = append(got, string(line))
+				return tt.limit == 0 || len(got) < tt.limit
+			})
+			if err != nil {
+				t.Fatalf("tailLines: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				if len(tt.content) > 1024 {
+					t.Errorf("got %d lines, want %d", len(got), len(tt.want))
+					return
+				}
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastIndexAligned(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+		sep  []byte
+		unit int
+		want int
+	}{
+		{"utf-8", []byte("a\nb\nc"), []byte("\n"), 1, 3},
+		{"not found", []byte("abc"), []byte("\n"), 1, -1},
+		{"aligned", []byte{'a', 0, '\n', 0, 'b', 0}, []byte{'\n', 0}, 2, 2},
+		{"misaligned match is skipped", []byte{'\n', 0, 0x41, '\n', 0, 0x4E}, []byte{'\n', 0}, 2, 0},
+		{"only a misaligned match", []byte{0x41, '\n', 0, 0x4E}, []byte{'\n', 0}, 2, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lastIndexAligned(tt.b, tt.sep, tt.unit); got != tt.want {
+				t.Errorf("lastIndexAligned = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
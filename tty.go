@@ -0,0 +1,47 @@
+package main
+
+import "os"
+
+// Exit codes AQS uses on its own behalf, so scripts can tell why nothing
+// ran. Once a command is executed, its exit code is returned instead, and
+// interruptions follow the 128 + signal convention. Closing the picker with
+// Esc counts as an interruption too (see fzfAbortCode).
+const (
+	exitNoSelection = 1 // picker accepted with nothing matching, confirmation declined or fzf missing
+	exitNoHistory   = 2 // no history (or session log) to search
+	exitNoMatch     = 3 // non-interactive run where nothing matched the query
+)
+
+// isTerminal reports whether f refers to a character device such as a
+// tty. /dev/null, which cron and CI commonly attach, does not count.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return false
+	}
+	return true
+}
+
+// isInteractive reports whether a user can drive the picker: AQS needs a
+// controlling terminal, and stdout or stderr attached to it. Capturing only
+// stdout, as in cmd=$(aqs -d), still counts as interactive.
+func isInteractive() bool {
+	if !isTerminal(os.Stdout) && !isTerminal(os.Stderr) {
+		return false
+	}
+	return hasControllingTerminal()
+}
+
+// hasControllingTerminal reports whether AQS can open its controlling
+// terminal, which fzf and interactive commands read from directly.
+func hasControllingTerminal() bool {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false
+	}
+	tty.Close()
+	return true
+}